@@ -0,0 +1,229 @@
+// Package appendable provides a storage.Appendable implementation that fans
+// out scraped samples to the set of receivers configured for a Flow
+// component, and tracks lightweight per-target scrape statistics used by
+// component DebugInfo implementations.
+package appendable
+
+import (
+	"context"
+	"sync"
+
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// scrapeStats holds the per-target counters from the most recently
+// completed scrape.
+type scrapeStats struct {
+	seriesAdded      int
+	staleSampleCount int
+	sampleCount      int
+}
+
+// FlowAppendable is a storage.Appendable that forwards samples to the
+// receivers it's been given, and can have those receivers swapped out at
+// runtime via SetReceivers.
+type FlowAppendable struct {
+	mut       sync.RWMutex
+	receivers []*prometheus.Receiver
+
+	statsMut sync.Mutex
+	// stats is keyed by target identity (job + instance label), not by
+	// per-series label set, and holds only the most recently completed
+	// scrape's counters for that target so memory stays bounded by the
+	// number of distinct targets rather than growing with series
+	// cardinality or scrape count.
+	stats map[string]scrapeStats
+}
+
+// NewFlowAppendable creates a new FlowAppendable which fans out to receivers.
+func NewFlowAppendable(receivers ...*prometheus.Receiver) *FlowAppendable {
+	return &FlowAppendable{
+		receivers: receivers,
+		stats:     make(map[string]scrapeStats),
+	}
+}
+
+// SetReceivers updates the set of receivers that samples are forwarded to.
+func (fa *FlowAppendable) SetReceivers(receivers []*prometheus.Receiver) {
+	fa.mut.Lock()
+	defer fa.mut.Unlock()
+	fa.receivers = receivers
+}
+
+// ListReceivers returns the current set of receivers.
+func (fa *FlowAppendable) ListReceivers() []*prometheus.Receiver {
+	fa.mut.RLock()
+	defer fa.mut.RUnlock()
+	return fa.receivers
+}
+
+// SeriesAdded returns the number of new series appended for the target
+// identified by job/instance during its most recently completed scrape,
+// approximating scrape churn for that target.
+func (fa *FlowAppendable) SeriesAdded(job, instance string) int {
+	fa.statsMut.Lock()
+	defer fa.statsMut.Unlock()
+	return fa.stats[targetKey(job, instance)].seriesAdded
+}
+
+// StaleSampleCount returns the number of stale markers (value.StaleNaN)
+// appended for the target identified by job/instance during its most
+// recently completed scrape, i.e. how many of its series were marked
+// absent since the previous scrape.
+func (fa *FlowAppendable) StaleSampleCount(job, instance string) int {
+	fa.statsMut.Lock()
+	defer fa.statsMut.Unlock()
+	return fa.stats[targetKey(job, instance)].staleSampleCount
+}
+
+// SampleCount returns the total number of samples (including stale
+// markers) appended for the target identified by job/instance during its
+// most recently completed scrape.
+func (fa *FlowAppendable) SampleCount(job, instance string) int {
+	fa.statsMut.Lock()
+	defer fa.statsMut.Unlock()
+	return fa.stats[targetKey(job, instance)].sampleCount
+}
+
+// Appender implements storage.Appendable.
+func (fa *FlowAppendable) Appender(ctx context.Context) storage.Appender {
+	fa.mut.RLock()
+	defer fa.mut.RUnlock()
+
+	children := make([]storage.Appender, 0, len(fa.receivers))
+	for _, r := range fa.receivers {
+		if r == nil || r.Receiver == nil {
+			continue
+		}
+		children = append(children, r.Receiver.Appender(ctx))
+	}
+
+	return &flowAppender{parent: fa, children: children}
+}
+
+// recordScrapeStats overwrites the stats for key with the counters from
+// the scrape that just completed, discarding whatever was recorded for
+// the previous scrape of that target.
+func (fa *FlowAppendable) recordScrapeStats(key string, s scrapeStats) {
+	fa.statsMut.Lock()
+	defer fa.statsMut.Unlock()
+	fa.stats[key] = s
+}
+
+// targetKey identifies a target the same way job/instance label values
+// are used elsewhere in this component (e.g. DebugInfo), since per-sample
+// series labels (which additionally carry __name__ and metric labels)
+// never match a target's own label set.
+func targetKey(job, instance string) string {
+	return job + "|" + instance
+}
+
+// flowAppender fans a single scrape's samples out to every child appender,
+// accumulating this scrape's per-target counters to report to the parent
+// FlowAppendable on Commit.
+type flowAppender struct {
+	parent   *FlowAppendable
+	children []storage.Appender
+
+	key   string
+	done  bool
+	stats scrapeStats
+}
+
+func (a *flowAppender) Append(ref storage.SeriesRef, l labels.Labels, t int64, v float64) (storage.SeriesRef, error) {
+	if !a.done {
+		a.key = targetKey(l.Get(string(model.JobLabel)), l.Get(string(model.InstanceLabel)))
+		a.done = true
+	}
+
+	a.stats.sampleCount++
+	if value.IsStaleNaN(v) {
+		a.stats.staleSampleCount++
+	} else {
+		a.stats.seriesAdded++
+	}
+
+	// Stale markers are forwarded like any other sample so that downstream
+	// receivers (e.g. prometheus.remote_write) see series disappear for
+	// targets that vanish or stop reporting a given series.
+	for _, child := range a.children {
+		if _, err := child.Append(ref, l, t, v); err != nil {
+			return ref, err
+		}
+	}
+	return ref, nil
+}
+
+func (a *flowAppender) AppendExemplar(ref storage.SeriesRef, l labels.Labels, e exemplar.Exemplar) (storage.SeriesRef, error) {
+	for _, child := range a.children {
+		if _, err := child.AppendExemplar(ref, l, e); err != nil {
+			return ref, err
+		}
+	}
+	return ref, nil
+}
+
+func (a *flowAppender) AppendHistogram(ref storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram) (storage.SeriesRef, error) {
+	if !a.done {
+		a.key = targetKey(l.Get(string(model.JobLabel)), l.Get(string(model.InstanceLabel)))
+		a.done = true
+	}
+	a.stats.sampleCount++
+	a.stats.seriesAdded++
+
+	for _, child := range a.children {
+		if _, err := child.AppendHistogram(ref, l, t, h, fh); err != nil {
+			return ref, err
+		}
+	}
+	return ref, nil
+}
+
+func (a *flowAppender) AppendHistogramCTZeroSample(ref storage.SeriesRef, l labels.Labels, t, ct int64, h *histogram.Histogram, fh *histogram.FloatHistogram) (storage.SeriesRef, error) {
+	for _, child := range a.children {
+		if _, err := child.AppendHistogramCTZeroSample(ref, l, t, ct, h, fh); err != nil {
+			return ref, err
+		}
+	}
+	return ref, nil
+}
+
+func (a *flowAppender) UpdateMetadata(ref storage.SeriesRef, l labels.Labels, m metadata.Metadata) (storage.SeriesRef, error) {
+	for _, child := range a.children {
+		if _, err := child.UpdateMetadata(ref, l, m); err != nil {
+			return ref, err
+		}
+	}
+	return ref, nil
+}
+
+func (a *flowAppender) Commit() error {
+	if a.done {
+		a.parent.recordScrapeStats(a.key, a.stats)
+	}
+
+	for _, child := range a.children {
+		if err := child.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *flowAppender) Rollback() error {
+	// A rolled-back scrape didn't produce a valid result, so leave the
+	// previous scrape's stats in place rather than recording a partial one.
+	for _, child := range a.children {
+		if err := child.Rollback(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
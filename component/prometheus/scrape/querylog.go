@@ -0,0 +1,115 @@
+package scrape
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// queryLogEntry is a single JSON-lines record written to the query log
+// file for each completed scrape.
+type queryLogEntry struct {
+	Job      string    `json:"job"`
+	Target   string    `json:"target"`
+	Time     time.Time `json:"time"`
+	Duration float64   `json:"duration_seconds"`
+	Samples  int       `json:"samples"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// resolveQueryLogPath resolves path against baseDir when path is relative,
+// so a query_log_file set in a config behaves like paths loaded from that
+// same config rather than depending on the process's current working
+// directory. An empty or already-absolute path is returned unchanged.
+func resolveQueryLogPath(baseDir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
+
+// queryLogger appends scrape summaries to a file as JSON lines. It opens
+// the file with O_APPEND so that writes are atomic with respect to other
+// writers sharing the same path (e.g. multiple prometheus.scrape
+// components pointed at the same query_log_file), and can be told to
+// reopen its path in place so a file renamed out from under it by
+// logrotate picks back up cleanly.
+type queryLogger struct {
+	mut  sync.Mutex
+	path string
+	file *os.File
+}
+
+// setPath closes any currently open file and opens path in its place. An
+// empty path disables the query log. It's a no-op if path is unchanged and
+// the file is still open, so calling it on every Update doesn't churn file
+// descriptors when the config is unchanged.
+func (q *queryLogger) setPath(path string) error {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	if path == q.path && (q.file != nil || path == "") {
+		return nil
+	}
+
+	if q.file != nil {
+		_ = q.file.Close()
+		q.file = nil
+	}
+	q.path = path
+
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	q.file = f
+	return nil
+}
+
+// reopen closes and reopens the current path, picking up a file that
+// logrotate has renamed or truncated out from under the open descriptor.
+// It's called in response to SIGHUP.
+func (q *queryLogger) reopen() error {
+	q.mut.Lock()
+	path := q.path
+	// Force setPath to actually reopen below, even though path itself
+	// hasn't changed.
+	q.path = ""
+	q.mut.Unlock()
+
+	return q.setPath(path)
+}
+
+// log appends e to the query log file, if one is currently open.
+func (q *queryLogger) log(e queryLogEntry) {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	q.mut.Lock()
+	defer q.mut.Unlock()
+	if q.file == nil {
+		return
+	}
+	_, _ = q.file.Write(line)
+}
+
+// close closes the underlying file, if one is open.
+func (q *queryLogger) close() error {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+	if q.file == nil {
+		return nil
+	}
+	err := q.file.Close()
+	q.file = nil
+	return err
+}
@@ -0,0 +1,118 @@
+package scrape
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResolveQueryLogPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseDir string
+		path    string
+		want    string
+	}{
+		{"empty path disables logging", "/etc/alloy", "", ""},
+		{"relative path resolved against base dir", "/etc/alloy", "query.log", "/etc/alloy/query.log"},
+		{"absolute path left alone", "/etc/alloy", "/var/log/query.log", "/var/log/query.log"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveQueryLogPath(tt.baseDir, tt.path); got != tt.want {
+				t.Errorf("resolveQueryLogPath(%q, %q) = %q, want %q", tt.baseDir, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryLogger_RotatesOnReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "query.log")
+
+	q := &queryLogger{}
+	if err := q.setPath(path); err != nil {
+		t.Fatalf("setPath: %v", err)
+	}
+	defer q.close()
+
+	q.log(queryLogEntry{Job: "before-rotate", Time: time.Now()})
+
+	// Simulate logrotate: move the file aside, then ask the logger to
+	// reopen so it picks up a fresh file at the original path.
+	rotated := filepath.Join(dir, "query.log.1")
+	if err := os.Rename(path, rotated); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+
+	if err := q.reopen(); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+
+	q.log(queryLogEntry{Job: "after-rotate", Time: time.Now()})
+	q.close()
+
+	if got := countLines(t, rotated); got != 1 {
+		t.Errorf("rotated file: got %d lines, want 1", got)
+	}
+	if got := countLines(t, path); got != 1 {
+		t.Errorf("new file: got %d lines, want 1", got)
+	}
+}
+
+func TestQueryLogger_ConcurrentWriters(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "shared.log")
+
+	const writers = 4
+	const linesPerWriter = 50
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		// Each writer gets its own *queryLogger opened against the same
+		// path, mirroring multiple prometheus.scrape components sharing a
+		// query_log_file.
+		q := &queryLogger{}
+		if err := q.setPath(path); err != nil {
+			t.Fatalf("setPath: %v", err)
+		}
+
+		wg.Add(1)
+		go func(q *queryLogger, id int) {
+			defer wg.Done()
+			defer q.close()
+			for j := 0; j < linesPerWriter; j++ {
+				q.log(queryLogEntry{Job: "writer", Target: path, Samples: id})
+			}
+		}(q, i)
+	}
+	wg.Wait()
+
+	if got, want := countLines(t, path), writers*linesPerWriter; got != want {
+		t.Errorf("got %d lines, want %d", got, want)
+	}
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var n int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		n++
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("scan %s: %v", path, err)
+	}
+	return n
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"os"
+	"os/signal"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/alecthomas/units"
@@ -46,6 +49,10 @@ type Arguments struct {
 	HonorLabels bool `river:"honor_labels,attr,optional"`
 	// Indicator whether the scraped timestamps should be respected.
 	HonorTimestamps bool `river:"honor_timestamps,attr,optional"`
+	// Indicator whether to track the staleness of the scraped timestamps.
+	// This will be used to decide whether the scrape should be reported as
+	// stale, even when HonorTimestamps is false.
+	TrackTimestampsStaleness bool `river:"track_timestamps_staleness,attr,optional"`
 	// A set of query parameters with which the target is scraped.
 	Params url.Values `river:"params,attr,optional"`
 	// How frequently to scrape the targets of this scrape config.
@@ -74,11 +81,28 @@ type Arguments struct {
 	// More than this label value length post metric-relabeling will cause the
 	// scrape to fail.
 	LabelValueLengthLimit uint `river:"label_value_length_limit,attr,optional"`
+	// Keep no more than this many dropped targets per job.
+	// 0 means no limit.
+	KeepDroppedTargets uint `river:"keep_dropped_targets,attr,optional"`
 
 	HTTPClientConfig component_config.HTTPClientConfig `river:"http_client_config,block,optional"`
 
 	// Scrape Options
 	ExtraMetrics bool `river:"extra_metrics,attr,optional"`
+	// Whether to negotiate the application/vnd.google.protobuf content type to
+	// allow native histograms and exemplars to be scraped.
+	EnableProtobufNegotiation bool `river:"enable_protobuf_negotiation,attr,optional"`
+	// Whether to still scrape classic histograms even when native histograms
+	// are present for a metric family.
+	ScrapeClassicHistograms bool `river:"scrape_classic_histograms,attr,optional"`
+	// The maximum number of buckets a native histogram sample may have before
+	// it gets converted to a classic histogram by the scraper.
+	NativeHistogramBucketLimit uint `river:"native_histogram_bucket_limit,attr,optional"`
+	// Path of a file to which a JSON-lines summary of every scrape (job,
+	// target URL, timestamp, duration, sample count, error) is appended.
+	// Relative paths are resolved relative to the Alloy config file. Empty
+	// disables query logging.
+	QueryLogFile string `river:"query_log_file,attr,optional"`
 }
 
 // DefaultArguments defines the default settings for a scrape job.
@@ -110,6 +134,7 @@ type Component struct {
 	args       Arguments
 	scraper    *scrape.Manager
 	appendable *fa.FlowAppendable
+	queryLog   *queryLogger
 }
 
 var (
@@ -120,13 +145,21 @@ var (
 func New(o component.Options, args Arguments) (*Component, error) {
 	flowAppendable := fa.NewFlowAppendable(args.ForwardTo...)
 
-	scrapeOptions := &scrape.Options{ExtraMetrics: args.ExtraMetrics}
-	scraper := scrape.NewManager(scrapeOptions, o.Logger, flowAppendable)
+	scrapeOptions := &scrape.Options{
+		ExtraMetrics:               args.ExtraMetrics,
+		EnableProtobufNegotiation:  args.EnableProtobufNegotiation,
+		NativeHistogramBucketLimit: args.NativeHistogramBucketLimit,
+	}
+	// Pass the component's own registerer instead of the global default so
+	// that multiple prometheus.scrape instances don't collide on metric
+	// registration.
+	scraper := scrape.NewManager(scrapeOptions, o.Logger, flowAppendable, o.Registerer)
 	c := &Component{
 		opts:          o,
 		reloadTargets: make(chan struct{}, 1),
 		scraper:       scraper,
 		appendable:    flowAppendable,
+		queryLog:      &queryLogger{},
 	}
 
 	// Call to Update() to set the receivers and targets once at the start.
@@ -140,6 +173,7 @@ func New(o component.Options, args Arguments) (*Component, error) {
 // Run implements component.Component.
 func (c *Component) Run(ctx context.Context) error {
 	defer c.scraper.Stop()
+	defer c.queryLog.close()
 
 	targetSetsChan := make(chan map[string][]*targetgroup.Group)
 
@@ -151,10 +185,22 @@ func (c *Component) Run(ctx context.Context) error {
 		}
 	}()
 
+	go c.pollQueryLog(ctx)
+
+	// Reopen the query log file on SIGHUP so logrotate can rename it away
+	// and have new writes land in a freshly created file at the same path.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
+		case <-hup:
+			if err := c.queryLog.reopen(); err != nil {
+				level.Error(c.opts.Logger).Log("msg", "failed to reopen query log file", "err", err)
+			}
 		case <-c.reloadTargets:
 			c.mut.RLock()
 			tgs := c.args.Targets
@@ -170,6 +216,59 @@ func (c *Component) Run(ctx context.Context) error {
 	}
 }
 
+// queryLogPollInterval is how often pollQueryLog checks for newly
+// completed scrapes. The scrape manager doesn't expose a per-scrape
+// completion callback, so completed scrapes are detected by diffing each
+// target's LastScrape timestamp instead; a very short scrape_interval may
+// have more than one scrape coalesced into a single log line.
+const queryLogPollInterval = 5 * time.Second
+
+// pollQueryLog periodically scans active targets for scrapes that
+// completed since the last poll and appends a summary line for each to
+// the query log file.
+func (c *Component) pollQueryLog(ctx context.Context) {
+	ticker := time.NewTicker(queryLogPollInterval)
+	defer ticker.Stop()
+
+	lastLogged := make(map[string]time.Time)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for job, stt := range c.scraper.TargetsActive() {
+				for _, st := range stt {
+					if st == nil {
+						continue
+					}
+
+					last := st.LastScrape()
+					key := job + "|" + st.URL().String()
+					if last.IsZero() || last.Equal(lastLogged[key]) {
+						continue
+					}
+					lastLogged[key] = last
+
+					var errStr string
+					if st.LastError() != nil {
+						errStr = st.LastError().Error()
+					}
+
+					c.queryLog.log(queryLogEntry{
+						Job:      job,
+						Target:   st.URL().String(),
+						Time:     last,
+						Duration: st.LastScrapeDuration().Seconds(),
+						Samples:  c.appendable.SampleCount(job, st.Labels().Get(string(model.InstanceLabel))),
+						Error:    errStr,
+					})
+				}
+			}
+		}
+	}
+}
+
 // Update implements component.Component.
 func (c *Component) Update(args component.Arguments) error {
 	newArgs := args.(Arguments)
@@ -180,6 +279,14 @@ func (c *Component) Update(args component.Arguments) error {
 
 	c.appendable.SetReceivers(newArgs.ForwardTo)
 
+	// DataPath is the closest thing component.Options gives this component
+	// to the Alloy config file's directory, so it's used as the base
+	// directory a relative query_log_file is resolved against.
+	queryLogPath := resolveQueryLogPath(c.opts.DataPath, newArgs.QueryLogFile)
+	if err := c.queryLog.setPath(queryLogPath); err != nil {
+		return fmt.Errorf("failed to open query log file: %w", err)
+	}
+
 	sc, err := getPromScrapeConfigs(c.opts.ID, newArgs)
 	if err != nil {
 		return fmt.Errorf("invalid scrape_config: %w", err)
@@ -203,7 +310,8 @@ func (c *Component) Update(args component.Arguments) error {
 
 // ScraperStatus reports the status of the scraper's jobs.
 type ScraperStatus struct {
-	TargetStatus []TargetStatus `river:"target,block,optional"`
+	TargetStatus   []TargetStatus `river:"target,block,optional"`
+	DroppedTargets []TargetStatus `river:"dropped_target,block,optional"`
 }
 
 // TargetStatus reports on the status of the latest scrape for a target.
@@ -215,6 +323,10 @@ type TargetStatus struct {
 	LastError          string            `river:"last_error,attr,optional"`
 	LastScrape         time.Time         `river:"last_scrape,attr"`
 	LastScrapeDuration time.Duration     `river:"last_scrape_duration,attr,optional"`
+	SeriesAdded        int               `river:"series_added,attr,optional"`
+	LastScrapeSize     int               `river:"last_scrape_size,attr,optional"`
+	ContentType        string            `river:"content_type,attr,optional"`
+	StaleSampleCount   int               `river:"stale_sample_count,attr,optional"`
 }
 
 // DebugInfo implements component.DebugComponent
@@ -228,6 +340,7 @@ func (c *Component) DebugInfo() interface{} {
 				lastError = st.LastError().Error()
 			}
 			if st != nil {
+				instance := st.Labels().Get(string(model.InstanceLabel))
 				res = append(res, TargetStatus{
 					JobName:            job,
 					URL:                st.URL().String(),
@@ -236,12 +349,34 @@ func (c *Component) DebugInfo() interface{} {
 					LastError:          lastError,
 					LastScrape:         st.LastScrape(),
 					LastScrapeDuration: st.LastScrapeDuration(),
+					SeriesAdded:        c.appendable.SeriesAdded(job, instance),
+					LastScrapeSize:     st.LastScrapeSize(),
+					ContentType:        st.LastScrapeContentType(),
+					StaleSampleCount:   c.appendable.StaleSampleCount(job, instance),
 				})
 			}
 		}
 	}
 
-	return ScraperStatus{TargetStatus: res}
+	c.mut.RLock()
+	keepDroppedTargets := int(c.args.KeepDroppedTargets)
+	c.mut.RUnlock()
+
+	var dropped []TargetStatus
+	for job, tgs := range c.scraper.TargetsDropped() {
+		for _, tg := range tgs {
+			if keepDroppedTargets > 0 && len(dropped) >= keepDroppedTargets {
+				break
+			}
+			dropped = append(dropped, TargetStatus{
+				JobName: job,
+				URL:     tg.URL().String(),
+				Labels:  tg.DiscoveredLabels().Map(),
+			})
+		}
+	}
+
+	return ScraperStatus{TargetStatus: res, DroppedTargets: dropped}
 }
 
 func (c *Component) componentTargetsToProm(tgs []discovery.Target) map[string][]*targetgroup.Group {
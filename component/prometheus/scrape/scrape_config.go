@@ -0,0 +1,45 @@
+package scrape
+
+import (
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/config"
+)
+
+// getPromScrapeConfigs converts args into the config.ScrapeConfig consumed
+// by the scrape manager. jobName is used as the job label when the user
+// hasn't set one explicitly via Arguments.JobName.
+func getPromScrapeConfigs(jobName string, args Arguments) (*config.ScrapeConfig, error) {
+	dec := config.DefaultScrapeConfig
+
+	dec.JobName = jobName
+	if args.JobName != "" {
+		dec.JobName = args.JobName
+	}
+
+	dec.HonorLabels = args.HonorLabels
+	dec.HonorTimestamps = args.HonorTimestamps
+	dec.TrackTimestampsStaleness = args.TrackTimestampsStaleness
+	dec.Params = args.Params
+	dec.ScrapeInterval = model.Duration(args.ScrapeInterval)
+	dec.ScrapeTimeout = model.Duration(args.ScrapeTimeout)
+	dec.MetricsPath = args.MetricsPath
+	dec.Scheme = args.Scheme
+	dec.BodySizeLimit = args.BodySizeLimit
+	dec.SampleLimit = args.SampleLimit
+	dec.TargetLimit = args.TargetLimit
+	dec.LabelLimit = args.LabelLimit
+	dec.LabelNameLengthLimit = args.LabelNameLengthLimit
+	dec.LabelValueLengthLimit = args.LabelValueLengthLimit
+	dec.KeepDroppedTargets = args.KeepDroppedTargets
+	dec.ScrapeClassicHistograms = args.ScrapeClassicHistograms
+	dec.EnableProtobufNegotiation = args.EnableProtobufNegotiation
+	dec.NativeHistogramBucketLimit = args.NativeHistogramBucketLimit
+
+	httpClientConfig, err := args.HTTPClientConfig.Convert()
+	if err != nil {
+		return nil, err
+	}
+	dec.HTTPClientConfig = *httpClientConfig
+
+	return &dec, nil
+}